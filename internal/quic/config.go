@@ -0,0 +1,48 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package quic
+
+import "crypto/tls"
+
+// A Config structures the configuration of a QUIC endpoint, shared across
+// every Conn that endpoint dials or accepts.
+type Config struct {
+	// TLSConfig is the TLS configuration used to establish the handshake.
+	// It must be non-nil and include at least one certificate or set
+	// GetCertificate for a server, and ServerName or InsecureSkipVerify
+	// for a client.
+	TLSConfig *tls.Config
+
+	// TLSProvider, if set, constructs the TLSHandshaker used to drive the
+	// handshake in place of the default crypto/tls-backed implementation.
+	// See TLSHandshaker for why this is useful.
+	TLSProvider TLSProvider
+
+	// MaxPostHandshakeMessageSize bounds the size of a single TLS
+	// post-handshake message (for example, NewSessionTicket). The default,
+	// if zero, is defaultMaxPostHandshakeMessageSize.
+	MaxPostHandshakeMessageSize int64
+
+	// Allow0RTT enables sending and receiving 0-RTT (early) data.
+	// On the client, it permits speculatively sending data before the
+	// handshake completes, using the limits remembered in SessionCache.
+	// On the server, it permits accepting and decrypting such data.
+	Allow0RTT bool
+
+	// SessionCache, on a client, stores the subset of a server's transport
+	// parameters that must be remembered to size 0-RTT data safely on a
+	// resumed connection to that server. It has no effect unless Allow0RTT
+	// is also set. See SessionCache for details.
+	SessionCache SessionCache
+
+	// ResumptionState, on a server, is called with the AppData attached by
+	// SendSessionTicket to the ticket a resuming client presents, as soon
+	// as that ticket is decrypted (before the handshake finishes), letting
+	// the application recover state such as negotiated limits without a
+	// fresh round trip.
+	ResumptionState func(appData []byte)
+}
@@ -0,0 +1,161 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package quic
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"time"
+)
+
+// connSide identifies which endpoint of a connection a Conn represents.
+type connSide int
+
+const (
+	clientSide connSide = iota
+	serverSide
+)
+
+// A Conn is a single QUIC connection.
+type Conn struct {
+	side   connSide
+	config *Config
+
+	tls     TLSHandshaker
+	peerCtx context.Context // ctx passed to Dial/Accept, stripped of cancellation
+
+	wkeys, rkeys         [numberSpaceCount]keys
+	wkeys0RTT, rkeys0RTT keys
+
+	crypto [numberSpaceCount]*cryptoStream
+
+	postHandshakeLimit   postHandshakeLimiter
+	pendingTicketAppData []byte
+	sessionTicketSent    bool
+
+	streams *streamsState
+
+	peerTransportParameters transportParameters
+	handshakeConfirmedAt    time.Time
+
+	testHooks connTestHooks
+}
+
+// connTestHooks lets tests observe handshake events without a real network.
+type connTestHooks interface {
+	handleTLSEvent(e TLSEvent)
+}
+
+func newConn(side connSide, config *Config) *Conn {
+	c := &Conn{side: side, config: config}
+	for i := range c.crypto {
+		c.crypto[i] = &cryptoStream{}
+	}
+	c.streams = &streamsState{conn: c}
+	return c
+}
+
+// Dial creates and starts a client QUIC connection to a server.
+// initialConnID is the randomly-chosen connection ID used to derive
+// Initial packet protection keys and is not reused by any other
+// connection. ctx is used only to propagate values to hooks such as
+// tls.Config.GetCertificate; canceling it does not abort an in-progress
+// handshake. See (*Conn).startTLS.
+func Dial(ctx context.Context, config *Config, localParams transportParameters) (*Conn, error) {
+	c := newConn(clientSide, config)
+	initialConnID := make([]byte, 8)
+	if _, err := rand.Read(initialConnID); err != nil {
+		return nil, err
+	}
+	if err := c.startTLS(ctx, time.Now(), initialConnID, localParams); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Accept creates and starts a server QUIC connection from a client's
+// Initial packet. ctx is used only to propagate values to hooks such as
+// tls.Config.GetConfigForClient; canceling it does not abort an
+// in-progress handshake. See (*Conn).startTLS.
+func Accept(ctx context.Context, config *Config, initialConnID []byte, localParams transportParameters) (*Conn, error) {
+	c := newConn(serverSide, config)
+	if err := c.startTLS(ctx, time.Now(), initialConnID, localParams); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// confirmHandshake marks the handshake as confirmed, per RFC 9001,
+// Section 4.1.2: at the server, this happens as soon as the handshake
+// completes; at the client, only once the HANDSHAKE_DONE frame arrives
+// (not modeled by this minimal scaffold). confirmHandshake is only ever
+// called for server connections here (see handleTLSEvents), so
+// isHandshakeConfirmed is never true, and must not be relied on, for a
+// client Conn.
+func (c *Conn) confirmHandshake(now time.Time) {
+	c.handshakeConfirmedAt = now
+}
+
+// isHandshakeConfirmed reports whether the handshake has been confirmed.
+// It is server-only in this package: nothing calls confirmHandshake for a
+// client Conn, so this always reports false on the client. Code that needs
+// a client-applicable signal must not gate on this method; see the number
+// space check in handleCrypto for an example of gating on something else
+// instead. See confirmHandshake.
+func (c *Conn) isHandshakeConfirmed() bool {
+	return !c.handshakeConfirmedAt.IsZero()
+}
+
+// receiveTransportParameters records the peer's transport parameters once
+// the TLS handshake has delivered them (TLSEventTransportParameters).
+func (c *Conn) receiveTransportParameters(p transportParameters) {
+	c.peerTransportParameters = p
+}
+
+// protectEarlyPacket seals payload for transmission at the Early
+// (0-RTT) encryption level, using the write keys installed by
+// handleTLSEvents from the TLS stack's TLSEventSetWriteSecret event at
+// TLSEncryptionLevelEarly. header is the packet's associated data (the
+// QUIC long header, protected separately via headerProtectionMask).
+func (c *Conn) protectEarlyPacket(header, payload []byte, pktNum int64) ([]byte, error) {
+	if c.wkeys0RTT.aead == nil {
+		return nil, errors.New("quic: no 0-RTT write keys available")
+	}
+	return c.wkeys0RTT.protect(header, payload, pktNum), nil
+}
+
+// unprotectEarlyPacket opens a packet protected at the Early encryption
+// level by the peer's matching write keys, using our read keys installed
+// from TLSEventSetReadSecret at TLSEncryptionLevelEarly.
+func (c *Conn) unprotectEarlyPacket(header, payload []byte, pktNum int64) ([]byte, error) {
+	if c.rkeys0RTT.aead == nil {
+		return nil, errors.New("quic: no 0-RTT read keys available")
+	}
+	return c.rkeys0RTT.unprotect(header, payload, pktNum)
+}
+
+// cryptoStream reassembles (on read) and buffers (on write) the data
+// carried in CRYPTO frames for one packet number space. This minimal
+// scaffold requires in-order delivery; a full implementation would buffer
+// and reorder data that arrives out of sequence.
+type cryptoStream struct {
+	out      []byte
+	inOffset int64
+}
+
+func (s *cryptoStream) write(b []byte) {
+	s.out = append(s.out, b...)
+}
+
+func (s *cryptoStream) handleCrypto(off int64, data []byte, fn func([]byte) error) error {
+	if off != s.inOffset {
+		return &transportError{code: errInternal, reason: "out-of-order CRYPTO data unsupported"}
+	}
+	s.inOffset += int64(len(data))
+	return fn(data)
+}
@@ -0,0 +1,57 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package quic
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type ctxKey struct{}
+
+// ctxCapturingHandshaker records the context passed to Start, so tests can
+// inspect what startTLS actually handed the TLSHandshaker, without needing
+// a real crypto/tls handshake to drive NextEvent to completion.
+type ctxCapturingHandshaker struct {
+	stubHandshaker
+	started context.Context
+}
+
+func (h *ctxCapturingHandshaker) Start(ctx context.Context) error {
+	h.started = ctx
+	return nil
+}
+
+func TestStartTLSStripsCancellationButKeepsValues(t *testing.T) {
+	h := &ctxCapturingHandshaker{}
+	config := &Config{
+		TLSProvider: func(c *Conn, isClient bool) TLSHandshaker { return h },
+	}
+	c := newConn(clientSide, config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = context.WithValue(ctx, ctxKey{}, "tenant-1")
+	cancel() // canceled before startTLS is even called
+
+	if err := c.startTLS(ctx, time.Now(), make([]byte, 8), transportParameters{}); err != nil {
+		t.Fatalf("startTLS: %v", err)
+	}
+
+	if h.started == nil {
+		t.Fatal("TLSHandshaker.Start was never called")
+	}
+	if got, want := h.started.Value(ctxKey{}), "tenant-1"; got != want {
+		t.Errorf("ctx passed to Start has value %v, want %v", got, want)
+	}
+	if err := h.started.Err(); err != nil {
+		t.Errorf("ctx passed to Start has Err() = %v, want nil (cancellation should not propagate)", err)
+	}
+	if c.peerCtx != h.started {
+		t.Errorf("c.peerCtx != the context passed to Start")
+	}
+}
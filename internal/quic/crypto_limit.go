@@ -0,0 +1,75 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package quic
+
+import "fmt"
+
+// defaultMaxPostHandshakeMessageSize is the default limit on the size of a
+// single TLS post-handshake message (for example, NewSessionTicket)
+// delivered over a CRYPTO stream, per the 16KiB TLS record maximum used as
+// guidance in RFC 8446 and rounded up to give room for the handshake message
+// header and extensions.
+const defaultMaxPostHandshakeMessageSize = 65536
+
+// postHandshakeLimiter bounds the size of TLS handshake messages arriving on
+// the Application Data CRYPTO stream after the handshake has completed. A
+// TLS handshake message begins with a 1-byte type and a 3-byte big-endian
+// length; without a check here, a peer can send a few bytes of a message
+// header claiming an enormous length and force us to buffer that many bytes
+// before recognizing the message is malformed.
+type postHandshakeLimiter struct {
+	max int64 // MaxPostHandshakeMessageSize from Config, or the default
+
+	haveHeader bool // have we parsed the 4-byte message header yet?
+	header     [4]byte
+	headerLen  int
+	remaining  int64 // bytes left in the current message, once known
+}
+
+func (l *postHandshakeLimiter) maxSize() int64 {
+	if l.max > 0 {
+		return l.max
+	}
+	return defaultMaxPostHandshakeMessageSize
+}
+
+// next consumes data from the front of the post-handshake crypto stream,
+// updating the limiter's view of the current message boundary. It returns an
+// error if doing so would exceed the configured message size limit.
+func (l *postHandshakeLimiter) next(data []byte) error {
+	for len(data) > 0 {
+		if !l.haveHeader {
+			n := copy(l.header[l.headerLen:], data)
+			l.headerLen += n
+			data = data[n:]
+			if l.headerLen < len(l.header) {
+				return nil
+			}
+			l.haveHeader = true
+			l.remaining = int64(l.header[1])<<16 | int64(l.header[2])<<8 | int64(l.header[3])
+			if l.remaining > l.maxSize() {
+				return &transportError{
+					code:   errCryptoBufferExceeded,
+					reason: fmt.Sprintf("post-handshake message length %v exceeds limit %v", l.remaining, l.maxSize()),
+				}
+			}
+			continue
+		}
+		n := int64(len(data))
+		if n > l.remaining {
+			n = l.remaining
+		}
+		data = data[n:]
+		l.remaining -= n
+		if l.remaining == 0 {
+			// Message complete; the next bytes start a new header.
+			l.haveHeader = false
+			l.headerLen = 0
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package quic
+
+import (
+	"strings"
+	"testing"
+)
+
+// postHandshakeMessage builds a TLS handshake message with the given body,
+// prefixed with a 1-byte type and 3-byte big-endian length.
+func postHandshakeMessage(msgType byte, body []byte) []byte {
+	b := []byte{msgType, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))}
+	return append(b, body...)
+}
+
+func TestPostHandshakeLimiterAcceptsMessageUnderLimit(t *testing.T) {
+	l := &postHandshakeLimiter{max: 16}
+	msg := postHandshakeMessage(4, make([]byte, 10))
+	if err := l.next(msg); err != nil {
+		t.Fatalf("next() = %v, want nil", err)
+	}
+}
+
+func TestPostHandshakeLimiterRejectsOversizedMessage(t *testing.T) {
+	l := &postHandshakeLimiter{max: 16}
+	msg := postHandshakeMessage(4, make([]byte, 17))
+	if err := l.next(msg); err == nil {
+		t.Fatalf("next() = nil, want error for oversized message")
+	}
+}
+
+func TestPostHandshakeLimiterTracksMultipleMessages(t *testing.T) {
+	l := &postHandshakeLimiter{max: 1024}
+	a := postHandshakeMessage(4, make([]byte, 10))
+	b := postHandshakeMessage(4, make([]byte, 20))
+	if err := l.next(append(a, b...)); err != nil {
+		t.Fatalf("next() = %v, want nil", err)
+	}
+}
+
+func TestPostHandshakeLimiterDefaultSize(t *testing.T) {
+	l := &postHandshakeLimiter{}
+	if got, want := l.maxSize(), int64(defaultMaxPostHandshakeMessageSize); got != want {
+		t.Errorf("maxSize() with MaxPostHandshakeMessageSize unset = %d, want %d", got, want)
+	}
+}
+
+// Exercises the limiter against adversarially fragmented input: a peer may
+// split a CRYPTO frame's bytes across many small reads, and the declared
+// message length may arrive split across those fragments too.
+func FuzzPostHandshakeLimiter(f *testing.F) {
+	f.Add([]byte{4, 0, 0, 0})
+	f.Add(postHandshakeMessage(4, make([]byte, 100)))
+	f.Add(postHandshakeMessage(4, make([]byte, 1<<20)))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		l := &postHandshakeLimiter{max: defaultMaxPostHandshakeMessageSize}
+		// Feed the input one byte at a time, the most adversarial possible
+		// fragmentation, and stop as soon as the limiter rejects it.
+		for i := range data {
+			if err := l.next(data[i : i+1]); err != nil {
+				if !strings.Contains(err.Error(), "exceeds limit") {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,32 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package quic
+
+import "fmt"
+
+// Transport error codes defined by RFC 9000, Section 20.1, plus the
+// crypto_limit-specific code used to reject an oversized post-handshake
+// message.
+const (
+	errInternal             = 0x1
+	errCryptoBufferExceeded = 0x0d
+)
+
+// A transportError is a QUIC CONNECTION_CLOSE error reported to the peer,
+// carrying one of the transport error codes above and a human-readable
+// reason that is not sent on the wire but is useful for local diagnostics.
+type transportError struct {
+	code   uint64
+	reason string
+}
+
+func (e *transportError) Error() string {
+	if e.reason != "" {
+		return fmt.Sprintf("quic: transport error %#x: %s", e.code, e.reason)
+	}
+	return fmt.Sprintf("quic: transport error %#x", e.code)
+}
@@ -0,0 +1,180 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package quic
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"hash"
+)
+
+// numberSpace identifies one of the three packet number spaces a QUIC
+// connection tracks loss recovery and ACKs in (RFC 9000, Section 12.3).
+// 0-RTT and 1-RTT packets share the Application Data number space; they are
+// protected with different keys (see Conn.rkeys0RTT/wkeys0RTT), but use the
+// same packet number sequence and the same ACK state.
+type numberSpace int
+
+const (
+	initialSpace numberSpace = iota
+	handshakeSpace
+	appDataSpace
+	numberSpaceCount
+)
+
+// initialSalt is the version-specific salt used to derive QUIC v1 Initial
+// packet protection keys from the connection ID chosen by the client.
+// https://www.rfc-editor.org/rfc/rfc9001#section-5.2
+var initialSalt = []byte{0x38, 0x76, 0x2c, 0xf7, 0xf5, 0x59, 0x34, 0xb3, 0x4d, 0x17, 0x9a, 0xe6, 0x4a, 0x4c, 0x80, 0xca, 0xdc, 0xcb, 0xb7, 0xa0}
+
+// keys holds the packet and header protection keys for one direction
+// (reading or writing) at one encryption level, derived from a TLS secret as
+// described in RFC 9001, Section 5.
+type keys struct {
+	suite   uint16
+	aead    cipher.AEAD
+	iv      []byte
+	hpBlock cipher.Block
+}
+
+// newKeys derives packet protection keys from a secret produced by the TLS
+// handshake (tls.QUICConn's QUICSetReadSecret/QUICSetWriteSecret events) for
+// the given negotiated cipher suite.
+//
+// Only the AES-GCM suites are supported; ChaCha20-Poly1305 requires a
+// dependency outside the standard library and is rejected.
+func newKeys(suite uint16, secret []byte) (keys, error) {
+	newHash, keyLen, err := suiteHash(suite)
+	if err != nil {
+		return keys{}, err
+	}
+	key := hkdfExpandLabel(newHash, secret, "quic key", keyLen)
+	iv := hkdfExpandLabel(newHash, secret, "quic iv", 12)
+	hp := hkdfExpandLabel(newHash, secret, "quic hp", keyLen)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return keys{}, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return keys{}, err
+	}
+	hpBlock, err := aes.NewCipher(hp)
+	if err != nil {
+		return keys{}, err
+	}
+	return keys{suite: suite, aead: aead, iv: iv, hpBlock: hpBlock}, nil
+}
+
+// initialKeys derives the Initial packet protection keys for both
+// directions from the client's chosen Initial connection ID.
+// https://www.rfc-editor.org/rfc/rfc9001#section-5.2
+func initialKeys(clientDstConnID []byte) (clientKeys, serverKeys keys) {
+	initialSecret := hkdfExtract(sha256.New, clientDstConnID, initialSalt)
+	clientSecret := hkdfExpandLabel(sha256.New, initialSecret, "client in", 32)
+	serverSecret := hkdfExpandLabel(sha256.New, initialSecret, "server in", 32)
+	// The Initial cipher suite is always TLS_AES_128_GCM_SHA256.
+	const initialSuite = 0x1301
+	ck, _ := newKeys(initialSuite, clientSecret)
+	sk, _ := newKeys(initialSuite, serverSecret)
+	return ck, sk
+}
+
+func suiteHash(suite uint16) (newHash func() hash.Hash, keyLen int, err error) {
+	switch suite {
+	case 0x1301: // TLS_AES_128_GCM_SHA256
+		return sha256.New, 16, nil
+	case 0x1302: // TLS_AES_256_GCM_SHA384
+		return sha512.New384, 32, nil
+	default:
+		return nil, 0, errors.New("quic: unsupported cipher suite for packet protection")
+	}
+}
+
+// protect seals a 0-RTT or 1-RTT packet's payload in place, as the last step
+// of packet construction: header, then payload, then the AEAD tag computed
+// over both. pktNum is the full (unprotected) packet number; header
+// protection of the first byte and packet number is applied by the caller
+// after protect returns, per RFC 9001, Section 5.4.
+func (k keys) protect(header, payload []byte, pktNum int64) []byte {
+	nonce := k.nonce(pktNum)
+	return k.aead.Seal(payload[:0], nonce, payload, header)
+}
+
+// unprotect authenticates and decrypts a packet payload protected by the
+// peer's matching keys. header must be the packet's associated data exactly
+// as protect saw it (after header protection has already been removed by
+// the caller).
+func (k keys) unprotect(header, payload []byte, pktNum int64) ([]byte, error) {
+	nonce := k.nonce(pktNum)
+	return k.aead.Open(payload[:0], nonce, payload, header)
+}
+
+// nonce computes the per-packet AEAD nonce: the IV XORed with the packet
+// number in its low bits, per RFC 9001, Section 5.3.
+func (k keys) nonce(pktNum int64) []byte {
+	nonce := make([]byte, len(k.iv))
+	copy(nonce, k.iv)
+	var pn [8]byte
+	binary.BigEndian.PutUint64(pn[:], uint64(pktNum))
+	for i := range pn {
+		nonce[len(nonce)-8+i] ^= pn[i]
+	}
+	return nonce
+}
+
+// headerProtectionMask returns the mask RFC 9001, Section 5.4.1 applies to
+// the first byte and packet number field of a packet, derived from the AEAD
+// sample taken from the protected payload.
+//
+// Nothing in this package calls headerProtectionMask yet: it is written in
+// anticipation of the packet encoder/decoder that a later change will add,
+// which is the only place a sample can be taken from an assembled packet.
+// Until then it is exercised only by TestHeaderProtectionMaskLength.
+func (k keys) headerProtectionMask(sample []byte) []byte {
+	mask := make([]byte, k.hpBlock.BlockSize())
+	k.hpBlock.Encrypt(mask, sample)
+	return mask
+}
+
+// hkdfExtract and hkdfExpandLabel implement the subset of RFC 5869 HKDF and
+// RFC 8446, Section 7.1's HKDF-Expand-Label that QUIC-TLS key derivation
+// needs, using only the standard library (avoiding a dependency on
+// golang.org/x/crypto/hkdf).
+func hkdfExtract(newHash func() hash.Hash, secret, salt []byte) []byte {
+	mac := hmac.New(newHash, salt)
+	mac.Write(secret)
+	return mac.Sum(nil)
+}
+
+func hkdfExpandLabel(newHash func() hash.Hash, secret []byte, label string, length int) []byte {
+	var info []byte
+	info = binary.BigEndian.AppendUint16(info, uint16(length))
+	fullLabel := "tls13 " + label
+	info = append(info, byte(len(fullLabel)))
+	info = append(info, fullLabel...)
+	info = append(info, 0) // no context
+
+	out := make([]byte, 0, length)
+	prev := []byte{}
+	mac := hmac.New(newHash, secret)
+	for len(out) < length {
+		mac.Reset()
+		mac.Write(prev)
+		mac.Write(info)
+		mac.Write([]byte{byte(len(out)/mac.Size() + 1)})
+		prev = mac.Sum(nil)
+		out = append(out, prev...)
+	}
+	return out[:length]
+}
@@ -0,0 +1,82 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package quic
+
+import "errors"
+
+// streamsState tracks the stream-level flow-control limits and buffered
+// stream data for a Conn. Only the subset needed to support speculative
+// 0-RTT data is modeled here.
+type streamsState struct {
+	conn *Conn
+
+	// Flow-control limits in effect for 0-RTT data sent before the real
+	// limits, negotiated in this connection's handshake, are known. They
+	// start at zero (no 0-RTT may be sent) until setEarly0RTTLimits seeds
+	// them from a previous connection to the same server.
+	maxDataEarly                 int64
+	maxStreamDataBidiLocalEarly  int64
+	maxStreamDataBidiRemoteEarly int64
+	maxStreamDataUniEarly        int64
+	maxStreamsBidiEarly          int64
+	maxStreamsUniEarly           int64
+
+	// sentEarly records stream data sent at the Early encryption level, in
+	// case it must be discarded after the peer rejects 0-RTT.
+	sentEarly [][]byte
+}
+
+// setEarly0RTTLimits seeds the provisional flow-control limits applied to
+// data sent at the Early encryption level, before the handshake delivers
+// the peer's real transport parameters for this connection. zp is the
+// subset of a previous connection's parameters that RFC 9001, Section 7.4.1
+// permits remembering for this purpose.
+func (s *streamsState) setEarly0RTTLimits(zp zeroRTTTransportParams) {
+	s.maxDataEarly = zp.initialMaxData
+	s.maxStreamDataBidiLocalEarly = zp.initialMaxStreamDataBidiLocal
+	s.maxStreamDataBidiRemoteEarly = zp.initialMaxStreamDataBidiRemote
+	s.maxStreamDataUniEarly = zp.initialMaxStreamDataUni
+	s.maxStreamsBidiEarly = zp.initialMaxStreamsBidi
+	s.maxStreamsUniEarly = zp.initialMaxStreamsUni
+}
+
+// discardSentEarlyData is called when the peer rejects 0-RTT
+// (TLSEventRejectedEarlyData). Everything sent speculatively at the Early
+// encryption level must be treated as though it was never sent, so it can
+// be retransmitted once the 1-RTT keys are available.
+func (s *streamsState) discardSentEarlyData() {
+	s.sentEarly = nil
+}
+
+// sendEarly and receiveEarly are written against the stream-level flow
+// control modeled above, in anticipation of the (not yet implemented)
+// packet engine that will frame and send stream data. Nothing calls them
+// yet; a later change adding that engine is expected to be the first real
+// caller, using these as the 0-RTT-specific send/receive path.
+
+// sendEarly protects stream data for transmission at the Early encryption
+// level and records it in sentEarly, which discardSentEarlyData clears if
+// the peer later rejects 0-RTT. It fails if data would exceed maxDataEarly, the
+// provisional limit seeded by setEarly0RTTLimits.
+func (s *streamsState) sendEarly(header, data []byte, pktNum int64) ([]byte, error) {
+	if int64(len(data)) > s.maxDataEarly {
+		return nil, errors.New("quic: 0-RTT data exceeds remembered flow-control limit")
+	}
+	protected, err := s.conn.protectEarlyPacket(header, data, pktNum)
+	if err != nil {
+		return nil, err
+	}
+	s.sentEarly = append(s.sentEarly, data)
+	s.maxDataEarly -= int64(len(data))
+	return protected, nil
+}
+
+// receiveEarly unprotects and returns stream data delivered in a 0-RTT
+// packet at the Early encryption level.
+func (s *streamsState) receiveEarly(header, protected []byte, pktNum int64) ([]byte, error) {
+	return s.conn.unprotectEarlyPacket(header, protected, pktNum)
+}
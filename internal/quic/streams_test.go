@@ -0,0 +1,79 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package quic
+
+import "testing"
+
+func newTestConnWithEarlyKeys(t *testing.T) *Conn {
+	t.Helper()
+	c := newConn(clientSide, &Config{})
+	k, err := newKeys(0x1301, make([]byte, 32))
+	if err != nil {
+		t.Fatalf("newKeys: %v", err)
+	}
+	c.wkeys0RTT = k
+	c.rkeys0RTT = k
+	return c
+}
+
+func TestSendEarlyEnforcesFlowControlLimit(t *testing.T) {
+	c := newTestConnWithEarlyKeys(t)
+	c.streams.setEarly0RTTLimits(zeroRTTTransportParams{initialMaxData: 4})
+
+	if _, err := c.streams.sendEarly([]byte("hdr"), []byte("12345"), 0); err == nil {
+		t.Fatal("sendEarly(5 bytes, limit 4) = nil error, want error")
+	}
+}
+
+func TestSendEarlyTracksRemainingLimitAndSentData(t *testing.T) {
+	c := newTestConnWithEarlyKeys(t)
+	c.streams.setEarly0RTTLimits(zeroRTTTransportParams{initialMaxData: 10})
+
+	if _, err := c.streams.sendEarly([]byte("hdr"), []byte("abcde"), 0); err != nil {
+		t.Fatalf("sendEarly: %v", err)
+	}
+	if got, want := c.streams.maxDataEarly, int64(5); got != want {
+		t.Errorf("maxDataEarly after sending 5 bytes = %d, want %d", got, want)
+	}
+	if len(c.streams.sentEarly) != 1 {
+		t.Fatalf("len(sentEarly) = %d, want 1", len(c.streams.sentEarly))
+	}
+
+	if _, err := c.streams.sendEarly([]byte("hdr"), []byte("abcdef"), 1); err == nil {
+		t.Fatal("sendEarly(6 bytes, remaining limit 5) = nil error, want error")
+	}
+}
+
+func TestDiscardSentEarlyDataClearsSentEarly(t *testing.T) {
+	c := newTestConnWithEarlyKeys(t)
+	c.streams.setEarly0RTTLimits(zeroRTTTransportParams{initialMaxData: 10})
+	if _, err := c.streams.sendEarly([]byte("hdr"), []byte("abcde"), 0); err != nil {
+		t.Fatalf("sendEarly: %v", err)
+	}
+
+	c.streams.discardSentEarlyData()
+	if c.streams.sentEarly != nil {
+		t.Errorf("sentEarly after discardSentEarlyData = %v, want nil", c.streams.sentEarly)
+	}
+}
+
+func TestSendReceiveEarlyRoundTrip(t *testing.T) {
+	c := newTestConnWithEarlyKeys(t)
+	c.streams.setEarly0RTTLimits(zeroRTTTransportParams{initialMaxData: 100})
+
+	protected, err := c.streams.sendEarly([]byte("hdr"), []byte("early data"), 0)
+	if err != nil {
+		t.Fatalf("sendEarly: %v", err)
+	}
+	got, err := c.streams.receiveEarly([]byte("hdr"), protected, 0)
+	if err != nil {
+		t.Fatalf("receiveEarly: %v", err)
+	}
+	if string(got) != "early data" {
+		t.Errorf("receiveEarly(sendEarly(data)) = %q, want %q", got, "early data")
+	}
+}
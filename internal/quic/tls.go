@@ -8,14 +8,19 @@ package quic
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
-	"fmt"
 	"time"
 )
 
 // startTLS starts the TLS handshake.
-func (c *Conn) startTLS(now time.Time, initialConnID []byte, params transportParameters) error {
+//
+// ctx is the context passed to Dial or Accept. We don't need or want a
+// context for cancelation here, canceling ctx must not abort an
+// in-progress handshake, but users can use it to plumb values through to
+// hooks defined in the tls.Config (GetCertificate, GetConfigForClient, and
+// so on receive it via ClientHelloInfo.Context()). We strip its
+// cancellation before storing it, so only the values survive.
+func (c *Conn) startTLS(ctx context.Context, now time.Time, initialConnID []byte, params transportParameters) error {
 	clientKeys, serverKeys := initialKeys(initialConnID)
 	if c.side == clientSide {
 		c.wkeys[initialSpace], c.rkeys[initialSpace] = clientKeys, serverKeys
@@ -23,17 +28,17 @@ func (c *Conn) startTLS(now time.Time, initialConnID []byte, params transportPar
 		c.wkeys[initialSpace], c.rkeys[initialSpace] = serverKeys, clientKeys
 	}
 
-	qconfig := &tls.QUICConfig{TLSConfig: c.config.TLSConfig}
-	if c.side == clientSide {
-		c.tls = tls.QUICClient(qconfig)
-	} else {
-		c.tls = tls.QUICServer(qconfig)
-	}
+	c.peerCtx = context.WithoutCancel(ctx)
+	c.tls = newTLSHandshaker(c, c.side == clientSide)
 	c.tls.SetTransportParameters(marshalTransportParameters(params))
-	// TODO: We don't need or want a context for cancelation here,
-	// but users can use a context to plumb values through to hooks defined
-	// in the tls.Config. Pass through a context.
-	if err := c.tls.Start(context.TODO()); err != nil {
+	c.postHandshakeLimit.max = c.config.MaxPostHandshakeMessageSize
+	if zp, ok := c.zeroRTTTransportParamsForDial(); ok {
+		// Seed provisional flow-control limits from a previous connection
+		// to this server, per RFC 9001 Section 7.4.1, so the application
+		// can start sending 0-RTT data before the real limits arrive.
+		c.streams.setEarly0RTTLimits(zp)
+	}
+	if err := c.tls.Start(c.peerCtx); err != nil {
 		return err
 	}
 	return c.handleTLSEvents(now)
@@ -46,50 +51,87 @@ func (c *Conn) handleTLSEvents(now time.Time) error {
 			c.testHooks.handleTLSEvent(e)
 		}
 		switch e.Kind {
-		case tls.QUICNoEvent:
+		case TLSEventNoEvent:
 			return nil
-		case tls.QUICSetReadSecret:
+		case TLSEventSetReadSecret:
+			if e.Level == TLSEncryptionLevelEarly {
+				// The TLS stack's decision to offer 0-RTT here was baked
+				// into the session ticket at issuance time (the EarlyData
+				// bool passed to SendSessionTicket), not read from the live
+				// Config: an operator who flips Allow0RTT off after issuing
+				// early-data-eligible tickets would otherwise still have
+				// this package accept 0-RTT from clients presenting one of
+				// the old tickets. Re-check the config here so Allow0RTT
+				// gates acceptance, not just past issuance. Leaving
+				// rkeys0RTT unset makes unprotectEarlyPacket reject any
+				// 0-RTT packets that arrive, since there are no keys to
+				// decrypt them with.
+				if c.side == serverSide && !c.config.Allow0RTT {
+					break
+				}
+				k, err := newKeys(e.Suite, e.Data)
+				if err != nil {
+					return err
+				}
+				c.rkeys0RTT = k
+				break
+			}
 			space, k, err := tlsKey(e)
 			if err != nil {
 				return err
 			}
 			c.rkeys[space] = k
-		case tls.QUICSetWriteSecret:
+		case TLSEventSetWriteSecret:
+			if e.Level == TLSEncryptionLevelEarly {
+				k, err := newKeys(e.Suite, e.Data)
+				if err != nil {
+					return err
+				}
+				c.wkeys0RTT = k
+				break
+			}
 			space, k, err := tlsKey(e)
 			if err != nil {
 				return err
 			}
 			c.wkeys[space] = k
-		case tls.QUICWriteData:
+		case TLSEventWriteData:
 			space, err := spaceForLevel(e.Level)
 			if err != nil {
 				return err
 			}
 			c.crypto[space].write(e.Data)
-		case tls.QUICHandshakeDone:
+		case TLSEventHandshakeDone:
 			if c.side == serverSide {
 				// "[...] the TLS handshake is considered confirmed
 				// at the server when the handshake completes."
 				// https://www.rfc-editor.org/rfc/rfc9001#section-4.1.2-1
 				c.confirmHandshake(now)
 				if !c.config.TLSConfig.SessionTicketsDisabled {
-					if err := c.tls.SendSessionTicket(false); err != nil {
+					if err := c.SendSessionTicket(SessionTicketOptions{}); err != nil {
 						return err
 					}
 				}
 			}
-		case tls.QUICTransportParameters:
+		case TLSEventTransportParameters:
 			params, err := unmarshalTransportParams(e.Data)
 			if err != nil {
 				return err
 			}
 			c.receiveTransportParameters(params)
+			c.rememberTransportParams(params)
+		case TLSEventRejectedEarlyData:
+			// The peer did not accept our 0-RTT data. Anything we sent
+			// speculatively at the Early encryption level must be treated
+			// as though it was never sent, so the application can retry it
+			// once the handshake completes at the usual 1-RTT level.
+			c.discardRejected0RTT()
 		}
 	}
 }
 
-// tlsKey returns the keys in a QUICSetReadSecret or QUICSetWriteSecret event.
-func tlsKey(e tls.QUICEvent) (numberSpace, keys, error) {
+// tlsKey returns the keys in a TLSEventSetReadSecret or TLSEventSetWriteSecret event.
+func tlsKey(e TLSEvent) (numberSpace, keys, error) {
 	space, err := spaceForLevel(e.Level)
 	if err != nil {
 		return 0, keys{}, err
@@ -101,33 +143,49 @@ func tlsKey(e tls.QUICEvent) (numberSpace, keys, error) {
 	return space, k, nil
 }
 
-func spaceForLevel(level tls.QUICEncryptionLevel) (numberSpace, error) {
+// spaceForLevel returns the packet number space an encryption level operates in.
+// 0-RTT (Early) packets share the Application Data number space with 1-RTT
+// packets; only the keys used to protect them differ, and those are tracked
+// separately in Conn.rkeys0RTT/wkeys0RTT.
+func spaceForLevel(level TLSEncryptionLevel) (numberSpace, error) {
 	switch level {
-	case tls.QUICEncryptionLevelInitial:
+	case TLSEncryptionLevelInitial:
 		return initialSpace, nil
-	case tls.QUICEncryptionLevelHandshake:
+	case TLSEncryptionLevelHandshake:
 		return handshakeSpace, nil
-	case tls.QUICEncryptionLevelApplication:
+	case TLSEncryptionLevelApplication, TLSEncryptionLevelEarly:
 		return appDataSpace, nil
 	default:
-		return 0, fmt.Errorf("quic: internal error: write handshake data at level %v", level)
+		return 0, errors.New("quic: internal error: write handshake data at unknown level")
 	}
 }
 
 // handleCrypto processes data received in a CRYPTO frame.
 func (c *Conn) handleCrypto(now time.Time, space numberSpace, off int64, data []byte) error {
-	var level tls.QUICEncryptionLevel
+	var level TLSEncryptionLevel
 	switch space {
 	case initialSpace:
-		level = tls.QUICEncryptionLevelInitial
+		level = TLSEncryptionLevelInitial
 	case handshakeSpace:
-		level = tls.QUICEncryptionLevelHandshake
+		level = TLSEncryptionLevelHandshake
 	case appDataSpace:
-		level = tls.QUICEncryptionLevelApplication
+		level = TLSEncryptionLevelApplication
 	default:
 		return errors.New("quic: internal error: received CRYPTO frame in unexpected number space")
 	}
 	err := c.crypto[space].handleCrypto(off, data, func(b []byte) error {
+		// Post-handshake messages (NewSessionTicket, for example) arrive on
+		// the Application Data CRYPTO stream: under TLS 1.3, the Finished
+		// exchanges happen at the Handshake level, so any CRYPTO data at
+		// the Application level is, by definition, already post-handshake.
+		// Gate on the space alone -- isHandshakeConfirmed is server-only
+		// (see its doc comment in conn.go) and would never become true for
+		// a client, leaving this limiter permanently disabled there.
+		if space == appDataSpace {
+			if err := c.postHandshakeLimit.next(b); err != nil {
+				return err
+			}
+		}
 		return c.tls.HandleData(level, b)
 	})
 	if err != nil {
@@ -135,3 +193,53 @@ func (c *Conn) handleCrypto(now time.Time, space numberSpace, off int64, data []
 	}
 	return c.handleTLSEvents(now)
 }
+
+// SessionTicketOptions carries parameters for the single session ticket sent
+// by (*Conn).SendSessionTicket. crypto/tls's QUIC integration supports
+// issuing at most one ticket per connection (tls.QUICConn.SendSessionTicket
+// errors on a second call), so there is no way for this package to send
+// a server-chosen number of tickets per connection against that backend;
+// SendSessionTicket's doc comment spells out the resulting one-shot
+// contract.
+type SessionTicketOptions struct {
+	// MaxEarlyData, when nonzero, advertises that the ticket may be used
+	// for 0-RTT. crypto/tls's QUIC API exposes early-data acceptance as a
+	// single per-ticket bool (SendSessionTicket), not a byte limit, so this
+	// only gates whether 0-RTT is offered at all; it does not itself bound
+	// how much early data the server will accept. It has no effect unless
+	// the server's Config also sets Allow0RTT.
+	MaxEarlyData uint32
+
+	// AppData is opaque application state associated with the ticket. A
+	// client that resumes using this ticket will have AppData echoed back
+	// to the server's Config.ResumptionState callback, letting the server
+	// recover state (such as the negotiated ALPN protocol or stream
+	// limits) without a fresh round trip.
+	AppData []byte
+}
+
+// SendSessionTicket sends a session ticket to the client.
+//
+// It may be called by server connections after the handshake completes.
+// When the handshake completes, handleTLSEvents itself calls it with zero
+// SessionTicketOptions unless TLSConfig.SessionTicketsDisabled is set, so
+// on such a connection a later call returns an error, and the ticket sent
+// does not allow 0-RTT and carries no AppData. It is a one-shot: the
+// underlying tls.QUICConn supports sending only a single ticket per
+// connection and errors on a second call, so there is no way to send
+// multiple tickets on one connection against today's crypto/tls. A caller
+// that wants several independently-resumable tickets for a client must
+// establish separate connections.
+func (c *Conn) SendSessionTicket(opts SessionTicketOptions) error {
+	if c.side != serverSide {
+		return errors.New("quic: SendSessionTicket called on client connection")
+	}
+	if c.sessionTicketSent {
+		return errors.New("quic: SendSessionTicket already called for this connection; only one ticket per connection is supported")
+	}
+	if err := c.tls.SendSessionTicket(opts); err != nil {
+		return err
+	}
+	c.sessionTicketSent = true
+	return c.handleTLSEvents(time.Now())
+}
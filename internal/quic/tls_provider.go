@@ -0,0 +1,207 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package quic
+
+import (
+	"context"
+	"crypto/tls"
+)
+
+// TLSEncryptionLevel identifies a QUIC encryption level.
+type TLSEncryptionLevel int
+
+const (
+	TLSEncryptionLevelInitial TLSEncryptionLevel = iota
+	TLSEncryptionLevelEarly
+	TLSEncryptionLevelHandshake
+	TLSEncryptionLevelApplication
+)
+
+// TLSEventKind identifies the kind of a TLSEvent.
+type TLSEventKind int
+
+const (
+	TLSEventNoEvent TLSEventKind = iota
+	TLSEventSetReadSecret
+	TLSEventSetWriteSecret
+	TLSEventWriteData
+	TLSEventHandshakeDone
+	TLSEventTransportParameters
+	TLSEventRejectedEarlyData
+)
+
+// TLSEvent is a single event produced by a TLSHandshaker, mirroring the
+// shape of crypto/tls's tls.QUICEvent so that the default implementation
+// can translate one to the other without loss.
+type TLSEvent struct {
+	Kind  TLSEventKind
+	Level TLSEncryptionLevel
+	Data  []byte
+
+	// Suite is the negotiated cipher suite, set on
+	// TLSEventSetReadSecret and TLSEventSetWriteSecret.
+	Suite uint16
+}
+
+// A TLSHandshaker drives the TLS 1.3 handshake used to establish a QUIC
+// connection. It abstracts the interaction in startTLS/handleTLSEvents
+// away from crypto/tls specifically, so that alternate TLS stacks -- a
+// uTLS-style fork that shapes the ClientHello to resist fingerprinting,
+// BoringSSL via cgo, the psiphon-tls fork used for censorship
+// circumvention, or a scripted handshaker substituted in tests -- can be
+// used in place of crypto/tls without forking this package.
+//
+// Set Config.TLSProvider to construct a TLSHandshaker other than the
+// default, crypto/tls-backed one.
+type TLSHandshaker interface {
+	// Start begins the handshake. ctx is used only to propagate values to
+	// hooks such as tls.Config.GetCertificate; canceling it does not abort
+	// an in-progress handshake.
+	Start(ctx context.Context) error
+
+	// NextEvent returns the next event produced by the handshake.
+	// It returns a TLSEvent with Kind == TLSEventNoEvent when there is
+	// nothing left to do until more data arrives.
+	NextEvent() TLSEvent
+
+	// HandleData delivers data received in a CRYPTO frame at the given
+	// encryption level.
+	HandleData(level TLSEncryptionLevel, data []byte) error
+
+	// SetTransportParameters supplies the local transport parameters to
+	// send to the peer during the handshake.
+	SetTransportParameters(params []byte)
+
+	// SendSessionTicket requests that a session ticket be sent to the
+	// client. It is only called on server connections, after the
+	// handshake has completed, and at most once per connection: (*Conn)
+	// .SendSessionTicket rejects a second call itself, so implementations
+	// can assume they will never be asked for more than one ticket on a
+	// given connection.
+	SendSessionTicket(opts SessionTicketOptions) error
+}
+
+// TLSProvider constructs the TLSHandshaker used for one side of one
+// connection. isClient reports whether the handshaker is for the client
+// side of the connection.
+type TLSProvider func(c *Conn, isClient bool) TLSHandshaker
+
+// newTLSHandshaker returns the TLSHandshaker to use for c, consulting
+// Config.TLSProvider if set and falling back to the crypto/tls-backed
+// default otherwise.
+func newTLSHandshaker(c *Conn, isClient bool) TLSHandshaker {
+	if c.config.TLSProvider != nil {
+		return c.config.TLSProvider(c, isClient)
+	}
+	return newCryptoTLSHandshaker(c, isClient)
+}
+
+// cryptoTLSHandshaker is the default TLSHandshaker, backed by crypto/tls's
+// QUIC support (tls.QUICConn).
+type cryptoTLSHandshaker struct {
+	c    *Conn
+	conn *tls.QUICConn
+}
+
+func newCryptoTLSHandshaker(c *Conn, isClient bool) TLSHandshaker {
+	// tls.QUICConfig has only a TLSConfig field: there is no QUICConfig-level
+	// knob to request 0-RTT support, and no tls.QUICStoreSession event. The
+	// server instead controls 0-RTT per ticket, via the bool it passes to
+	// (*tls.QUICConn).SendSessionTicket (see (*Conn).SendSessionTicket
+	// in tls.go), and we hook session-ticket (de)serialization directly through
+	// tls.Config.WrapSession/UnwrapSession to carry our own state.
+	tlsConfig := c.config.TLSConfig
+	if !isClient && c.config.ResumptionState != nil {
+		// WrapSession and UnwrapSession close over c, so we need our own
+		// copy of the tls.Config rather than mutating the one shared
+		// across every Conn using this quic.Config.
+		configCopy := tlsConfig.Clone()
+		configCopy.WrapSession = c.wrapSession
+		configCopy.UnwrapSession = c.unwrapSession
+		tlsConfig = configCopy
+	}
+	h := &cryptoTLSHandshaker{c: c}
+	qconfig := &tls.QUICConfig{TLSConfig: tlsConfig}
+	if isClient {
+		h.conn = tls.QUICClient(qconfig)
+	} else {
+		h.conn = tls.QUICServer(qconfig)
+	}
+	return h
+}
+
+func (h *cryptoTLSHandshaker) Start(ctx context.Context) error {
+	return h.conn.Start(ctx)
+}
+
+func (h *cryptoTLSHandshaker) SetTransportParameters(params []byte) {
+	h.conn.SetTransportParameters(params)
+}
+
+func (h *cryptoTLSHandshaker) SendSessionTicket(opts SessionTicketOptions) error {
+	h.c.pendingTicketAppData = opts.AppData
+	defer func() { h.c.pendingTicketAppData = nil }()
+	// (*tls.QUICConn).SendSessionTicket's QUICSessionTicketOptions carries a
+	// single bool, EarlyData: whether the ticket being issued may be used
+	// for 0-RTT. There is no per-ticket way to attach more than that.
+	return h.conn.SendSessionTicket(tls.QUICSessionTicketOptions{
+		EarlyData: opts.MaxEarlyData > 0 && h.c.config.Allow0RTT,
+	})
+}
+
+func (h *cryptoTLSHandshaker) HandleData(level TLSEncryptionLevel, data []byte) error {
+	return h.conn.HandleData(qtlsLevel(level), data)
+}
+
+// NextEvent translates the next *tls.QUICConn event into a TLSEvent.
+func (h *cryptoTLSHandshaker) NextEvent() TLSEvent {
+	e := h.conn.NextEvent()
+	switch e.Kind {
+	case tls.QUICNoEvent:
+		return TLSEvent{Kind: TLSEventNoEvent}
+	case tls.QUICSetReadSecret:
+		return TLSEvent{Kind: TLSEventSetReadSecret, Level: quicLevel(e.Level), Data: e.Data, Suite: e.Suite}
+	case tls.QUICSetWriteSecret:
+		return TLSEvent{Kind: TLSEventSetWriteSecret, Level: quicLevel(e.Level), Data: e.Data, Suite: e.Suite}
+	case tls.QUICWriteData:
+		return TLSEvent{Kind: TLSEventWriteData, Level: quicLevel(e.Level), Data: e.Data}
+	case tls.QUICTransportParameters:
+		return TLSEvent{Kind: TLSEventTransportParameters, Data: e.Data}
+	case tls.QUICRejectedEarlyData:
+		return TLSEvent{Kind: TLSEventRejectedEarlyData}
+	case tls.QUICHandshakeDone:
+		return TLSEvent{Kind: TLSEventHandshakeDone}
+	default:
+		return TLSEvent{Kind: TLSEventNoEvent}
+	}
+}
+
+func qtlsLevel(level TLSEncryptionLevel) tls.QUICEncryptionLevel {
+	switch level {
+	case TLSEncryptionLevelInitial:
+		return tls.QUICEncryptionLevelInitial
+	case TLSEncryptionLevelEarly:
+		return tls.QUICEncryptionLevelEarly
+	case TLSEncryptionLevelHandshake:
+		return tls.QUICEncryptionLevelHandshake
+	default:
+		return tls.QUICEncryptionLevelApplication
+	}
+}
+
+func quicLevel(level tls.QUICEncryptionLevel) TLSEncryptionLevel {
+	switch level {
+	case tls.QUICEncryptionLevelInitial:
+		return TLSEncryptionLevelInitial
+	case tls.QUICEncryptionLevelEarly:
+		return TLSEncryptionLevelEarly
+	case tls.QUICEncryptionLevelHandshake:
+		return TLSEncryptionLevelHandshake
+	default:
+		return TLSEncryptionLevelApplication
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package quic
+
+import (
+	"context"
+	"testing"
+)
+
+func TestQUICEncryptionLevelRoundTrip(t *testing.T) {
+	for _, level := range []TLSEncryptionLevel{
+		TLSEncryptionLevelInitial,
+		TLSEncryptionLevelEarly,
+		TLSEncryptionLevelHandshake,
+		TLSEncryptionLevelApplication,
+	} {
+		if got := quicLevel(qtlsLevel(level)); got != level {
+			t.Errorf("quicLevel(qtlsLevel(%v)) = %v, want %v", level, got, level)
+		}
+	}
+}
+
+// stubHandshaker is a minimal TLSHandshaker used to verify that
+// Config.TLSProvider is consulted in place of the crypto/tls-backed default.
+type stubHandshaker struct{}
+
+func (stubHandshaker) Start(ctx context.Context) error                        { return nil }
+func (stubHandshaker) NextEvent() TLSEvent                                    { return TLSEvent{Kind: TLSEventNoEvent} }
+func (stubHandshaker) HandleData(level TLSEncryptionLevel, data []byte) error { return nil }
+func (stubHandshaker) SetTransportParameters(params []byte)                   {}
+func (stubHandshaker) SendSessionTicket(opts SessionTicketOptions) error      { return nil }
+
+func TestNewTLSHandshakerUsesConfiguredProvider(t *testing.T) {
+	var gotConn *Conn
+	var gotIsClient bool
+	stub := stubHandshaker{}
+	config := &Config{
+		TLSProvider: func(c *Conn, isClient bool) TLSHandshaker {
+			gotConn, gotIsClient = c, isClient
+			return stub
+		},
+	}
+	c := newConn(clientSide, config)
+
+	h := newTLSHandshaker(c, true)
+	if h != TLSHandshaker(stub) {
+		t.Errorf("newTLSHandshaker with Config.TLSProvider set = %v, want the stub returned by the provider", h)
+	}
+	if gotConn != c || !gotIsClient {
+		t.Errorf("TLSProvider called with (%v, %v), want (%v, true)", gotConn, gotIsClient, c)
+	}
+}
+
+func TestNewTLSHandshakerDefaultsToCryptoTLS(t *testing.T) {
+	c := newConn(clientSide, &Config{})
+	h := newTLSHandshaker(c, true)
+	if _, ok := h.(*cryptoTLSHandshaker); !ok {
+		t.Errorf("newTLSHandshaker with no Config.TLSProvider = %T, want *cryptoTLSHandshaker", h)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package quic
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSendSessionTicketRejectsClientConn(t *testing.T) {
+	c := newConn(clientSide, &Config{})
+	err := c.SendSessionTicket(SessionTicketOptions{})
+	if err == nil {
+		t.Fatal("SendSessionTicket on a client Conn = nil error, want error")
+	}
+	if !strings.Contains(err.Error(), "client") {
+		t.Errorf("SendSessionTicket on a client Conn error = %q, want mention of client connection", err)
+	}
+}
+
+func TestSendSessionTicketRejectsSecondCall(t *testing.T) {
+	c := newConn(serverSide, &Config{})
+	c.tls = &stubHandshaker{}
+	if err := c.SendSessionTicket(SessionTicketOptions{}); err != nil {
+		t.Fatalf("first SendSessionTicket: %v", err)
+	}
+	err := c.SendSessionTicket(SessionTicketOptions{})
+	if err == nil {
+		t.Fatal("second SendSessionTicket = nil error, want error (only one ticket per connection is supported)")
+	}
+	if !strings.Contains(err.Error(), "already called") {
+		t.Errorf("second SendSessionTicket error = %q, want mention that it was already called", err)
+	}
+}
+
+// scriptedHandshaker replays a fixed sequence of events, so tests can drive
+// handleTLSEvents without a real crypto/tls handshake.
+type scriptedHandshaker struct {
+	stubHandshaker
+	events []TLSEvent
+}
+
+func (h *scriptedHandshaker) NextEvent() TLSEvent {
+	if len(h.events) == 0 {
+		return TLSEvent{Kind: TLSEventNoEvent}
+	}
+	e := h.events[0]
+	h.events = h.events[1:]
+	return e
+}
+
+// TestServerHonorsAllow0RTTAtAcceptTime checks that a server re-checks
+// Config.Allow0RTT when the TLS stack offers 0-RTT read keys, rather than
+// trusting whatever decision was baked into the session ticket at issuance.
+// This covers an operator disabling Allow0RTT after already issuing
+// early-data-eligible tickets: a client presenting one of those tickets must
+// not have its 0-RTT data accepted.
+func TestServerHonorsAllow0RTTAtAcceptTime(t *testing.T) {
+	earlySecret := TLSEvent{Kind: TLSEventSetReadSecret, Level: TLSEncryptionLevelEarly, Suite: 0x1301, Data: make([]byte, 32)}
+
+	for _, test := range []struct {
+		name      string
+		allow0RTT bool
+		wantKeys  bool
+	}{
+		{"disabled", false, false},
+		{"enabled", true, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c := newConn(serverSide, &Config{Allow0RTT: test.allow0RTT})
+			c.tls = &scriptedHandshaker{events: []TLSEvent{earlySecret}}
+			if err := c.handleTLSEvents(time.Now()); err != nil {
+				t.Fatalf("handleTLSEvents: %v", err)
+			}
+			if got := c.rkeys0RTT.aead != nil; got != test.wantKeys {
+				t.Errorf("rkeys0RTT installed = %v, want %v", got, test.wantKeys)
+			}
+		})
+	}
+}
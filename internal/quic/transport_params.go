@@ -0,0 +1,102 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package quic
+
+// transportParameters holds the subset of the QUIC transport parameters
+// (RFC 9000, Section 18.2) that this package negotiates and exchanges
+// during the TLS handshake.
+type transportParameters struct {
+	initialMaxData                 int64
+	initialMaxStreamDataBidiLocal  int64
+	initialMaxStreamDataBidiRemote int64
+	initialMaxStreamDataUni        int64
+	initialMaxStreamsBidi          int64
+	initialMaxStreamsUni           int64
+	activeConnIDLimit              int64
+}
+
+// Transport parameter IDs, RFC 9000, Section 18.2.
+const (
+	paramInitialMaxData                 = 0x04
+	paramInitialMaxStreamDataBidiLocal  = 0x05
+	paramInitialMaxStreamDataBidiRemote = 0x06
+	paramInitialMaxStreamDataUni        = 0x07
+	paramInitialMaxStreamsBidi          = 0x08
+	paramInitialMaxStreamsUni           = 0x09
+	paramActiveConnIDLimit              = 0x0e
+)
+
+// marshalTransportParameters encodes p as the payload of a TLS
+// quic_transport_parameters extension (RFC 9000, Section 18.2): a sequence
+// of (id, length, value) tuples, each varint-encoded.
+func marshalTransportParameters(p transportParameters) []byte {
+	var b []byte
+	for _, f := range []struct {
+		id  uint64
+		val int64
+	}{
+		{paramInitialMaxData, p.initialMaxData},
+		{paramInitialMaxStreamDataBidiLocal, p.initialMaxStreamDataBidiLocal},
+		{paramInitialMaxStreamDataBidiRemote, p.initialMaxStreamDataBidiRemote},
+		{paramInitialMaxStreamDataUni, p.initialMaxStreamDataUni},
+		{paramInitialMaxStreamsBidi, p.initialMaxStreamsBidi},
+		{paramInitialMaxStreamsUni, p.initialMaxStreamsUni},
+		{paramActiveConnIDLimit, p.activeConnIDLimit},
+	} {
+		if f.val == 0 {
+			continue
+		}
+		val := appendVarint(nil, uint64(f.val))
+		b = appendVarint(b, f.id)
+		b = appendVarint(b, uint64(len(val)))
+		b = append(b, val...)
+	}
+	return b
+}
+
+// unmarshalTransportParams decodes the payload of a peer's
+// quic_transport_parameters extension. Unknown parameter IDs are ignored,
+// per RFC 9000, Section 7.4.
+func unmarshalTransportParams(b []byte) (transportParameters, error) {
+	var p transportParameters
+	for len(b) > 0 {
+		id, n := consumeVarint(b)
+		if n < 0 {
+			return transportParameters{}, &transportError{code: errInternal, reason: "invalid transport parameter"}
+		}
+		b = b[n:]
+		length, n := consumeVarint(b)
+		if n < 0 || uint64(len(b)-n) < length {
+			return transportParameters{}, &transportError{code: errInternal, reason: "invalid transport parameter"}
+		}
+		b = b[n:]
+		val := b[:length]
+		b = b[length:]
+
+		v, n := consumeVarint(val)
+		if n < 0 {
+			continue
+		}
+		switch id {
+		case paramInitialMaxData:
+			p.initialMaxData = int64(v)
+		case paramInitialMaxStreamDataBidiLocal:
+			p.initialMaxStreamDataBidiLocal = int64(v)
+		case paramInitialMaxStreamDataBidiRemote:
+			p.initialMaxStreamDataBidiRemote = int64(v)
+		case paramInitialMaxStreamDataUni:
+			p.initialMaxStreamDataUni = int64(v)
+		case paramInitialMaxStreamsBidi:
+			p.initialMaxStreamsBidi = int64(v)
+		case paramInitialMaxStreamsUni:
+			p.initialMaxStreamsUni = int64(v)
+		case paramActiveConnIDLimit:
+			p.activeConnIDLimit = int64(v)
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package quic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTransportParametersRoundTrip(t *testing.T) {
+	p := transportParameters{
+		initialMaxData:                 1000,
+		initialMaxStreamDataBidiLocal:  100,
+		initialMaxStreamDataBidiRemote: 200,
+		initialMaxStreamDataUni:        300,
+		initialMaxStreamsBidi:          10,
+		initialMaxStreamsUni:           5,
+		activeConnIDLimit:              4,
+	}
+	got, err := unmarshalTransportParams(marshalTransportParameters(p))
+	if err != nil {
+		t.Fatalf("unmarshalTransportParams: %v", err)
+	}
+	if !reflect.DeepEqual(got, p) {
+		t.Errorf("unmarshalTransportParams(marshalTransportParameters(p)) = %+v, want %+v", got, p)
+	}
+}
+
+func TestTransportParametersZeroFieldsOmitted(t *testing.T) {
+	// Zero-valued parameters aren't sent on the wire, per marshalTransportParameters,
+	// so an all-zero struct should round-trip to an empty encoding.
+	b := marshalTransportParameters(transportParameters{})
+	if len(b) != 0 {
+		t.Errorf("marshalTransportParameters(zero value) = %x, want empty", b)
+	}
+}
+
+func TestUnmarshalTransportParamsIgnoresUnknownID(t *testing.T) {
+	b := appendVarint(nil, 0xff) // unknown parameter ID
+	b = appendVarint(b, 1)       // length
+	b = append(b, 0)             // value
+	b = appendVarint(b, paramActiveConnIDLimit)
+	b = appendVarint(b, 1)
+	b = append(b, 7)
+
+	got, err := unmarshalTransportParams(b)
+	if err != nil {
+		t.Fatalf("unmarshalTransportParams: %v", err)
+	}
+	if got.activeConnIDLimit != 7 {
+		t.Errorf("activeConnIDLimit = %d, want 7", got.activeConnIDLimit)
+	}
+}
+
+func TestUnmarshalTransportParamsInvalid(t *testing.T) {
+	if _, err := unmarshalTransportParams([]byte{0xff}); err == nil {
+		t.Fatal("unmarshalTransportParams(truncated) = nil error, want error")
+	}
+}
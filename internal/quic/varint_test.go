@@ -0,0 +1,26 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package quic
+
+import "testing"
+
+func TestVarintRoundTrip(t *testing.T) {
+	for _, v := range []uint64{0, 1, 63, 64, 16383, 16384, 1073741823, 1073741824, 1<<62 - 1} {
+		b := appendVarint(nil, v)
+		got, n := consumeVarint(b)
+		if n != len(b) || got != v {
+			t.Errorf("roundtrip %d: consumeVarint(%x) = %d, %d; want %d, %d", v, b, got, n, v, len(b))
+		}
+	}
+}
+
+func TestConsumeVarintTruncated(t *testing.T) {
+	b := appendVarint(nil, 16384)
+	if _, n := consumeVarint(b[:1]); n != -1 {
+		t.Errorf("consumeVarint(truncated) = n %d, want -1", n)
+	}
+}
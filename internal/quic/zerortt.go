@@ -0,0 +1,151 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package quic
+
+import "crypto/tls"
+
+// zeroRTTTransportParams is the subset of transport parameters that RFC 9001
+// Section 7.4.1 requires a client to remember across connections and apply
+// to 0-RTT data sent before the server's actual parameters for the new
+// connection have been received.
+//
+// https://www.rfc-editor.org/rfc/rfc9001#section-7.4.1
+type zeroRTTTransportParams struct {
+	initialMaxData                 int64
+	initialMaxStreamDataBidiLocal  int64
+	initialMaxStreamDataBidiRemote int64
+	initialMaxStreamDataUni        int64
+	initialMaxStreamsBidi          int64
+	initialMaxStreamsUni           int64
+	activeConnIDLimit              int64
+}
+
+// marshal encodes the remembered parameters for storage in a SessionCache.
+func (p zeroRTTTransportParams) marshal() []byte {
+	b := make([]byte, 0, 7*8)
+	for _, v := range []int64{
+		p.initialMaxData,
+		p.initialMaxStreamDataBidiLocal,
+		p.initialMaxStreamDataBidiRemote,
+		p.initialMaxStreamDataUni,
+		p.initialMaxStreamsBidi,
+		p.initialMaxStreamsUni,
+		p.activeConnIDLimit,
+	} {
+		b = appendVarint(b, uint64(v))
+	}
+	return b
+}
+
+func unmarshalZeroRTTTransportParams(b []byte) (p zeroRTTTransportParams, ok bool) {
+	fields := []*int64{
+		&p.initialMaxData,
+		&p.initialMaxStreamDataBidiLocal,
+		&p.initialMaxStreamDataBidiRemote,
+		&p.initialMaxStreamDataUni,
+		&p.initialMaxStreamsBidi,
+		&p.initialMaxStreamsUni,
+		&p.activeConnIDLimit,
+	}
+	for _, f := range fields {
+		v, n := consumeVarint(b)
+		if n < 0 {
+			return zeroRTTTransportParams{}, false
+		}
+		*f = int64(v)
+		b = b[n:]
+	}
+	return p, true
+}
+
+// zeroRTTParamsFromTransportParameters extracts the subset of params that a
+// client is permitted to remember and reuse for a future 0-RTT attempt.
+func zeroRTTParamsFromTransportParameters(p transportParameters) zeroRTTTransportParams {
+	return zeroRTTTransportParams{
+		initialMaxData:                 p.initialMaxData,
+		initialMaxStreamDataBidiLocal:  p.initialMaxStreamDataBidiLocal,
+		initialMaxStreamDataBidiRemote: p.initialMaxStreamDataBidiRemote,
+		initialMaxStreamDataUni:        p.initialMaxStreamDataUni,
+		initialMaxStreamsBidi:          p.initialMaxStreamsBidi,
+		initialMaxStreamsUni:           p.initialMaxStreamsUni,
+		activeConnIDLimit:              p.activeConnIDLimit,
+	}
+}
+
+// SessionCache is consulted by client connections to remember the subset of
+// a server's transport parameters that RFC 9001 Section 7.4.1 requires
+// applying to 0-RTT data sent on a resumed connection, before the server's
+// parameters for that new connection arrive. It has nothing to do with
+// session tickets themselves, which crypto/tls's tls.Config.ClientSessionCache
+// already handles; this cache only holds the small, unauthenticated hint
+// needed to size 0-RTT flow control. Get and Put are keyed by server name.
+type SessionCache interface {
+	Get(serverName string) (params []byte, ok bool)
+	Put(serverName string, params []byte)
+}
+
+// rememberTransportParams saves the transport parameters the server just
+// sent us, so a future connection to the same server can apply them to
+// speculative 0-RTT data. It is a no-op unless we are a client configured
+// with both Allow0RTT and a SessionCache.
+func (c *Conn) rememberTransportParams(p transportParameters) {
+	if c.side != clientSide || !c.config.Allow0RTT || c.config.SessionCache == nil {
+		return
+	}
+	c.config.SessionCache.Put(c.config.TLSConfig.ServerName, zeroRTTParamsFromTransportParameters(p).marshal())
+}
+
+// zeroRTTTransportParamsForDial looks up the transport parameters remembered
+// from a previous connection to the server we are dialing, for use as
+// provisional flow-control limits while sending 0-RTT data. The second
+// return value is false if we have no cached parameters (or none are
+// needed), in which case 0-RTT data must not be sent until the handshake
+// completes.
+func (c *Conn) zeroRTTTransportParamsForDial() (zeroRTTTransportParams, bool) {
+	if c.side != clientSide || !c.config.Allow0RTT || c.config.SessionCache == nil {
+		return zeroRTTTransportParams{}, false
+	}
+	raw, ok := c.config.SessionCache.Get(c.config.TLSConfig.ServerName)
+	if !ok {
+		return zeroRTTTransportParams{}, false
+	}
+	return unmarshalZeroRTTTransportParams(raw)
+}
+
+// wrapSession is set as tls.Config.WrapSession on the server when
+// Config.ResumptionState is set. It is the encode half of the
+// WrapSession/UnwrapSession pair: it attaches the pending ticket's
+// application data (see (*Conn).SendSessionTicket) to the serialized
+// session state that unwrapSession will later parse back out.
+func (c *Conn) wrapSession(cs tls.ConnectionState, ss *tls.SessionState) ([]byte, error) {
+	ss.Extra = append(ss.Extra, c.pendingTicketAppData)
+	return ss.Bytes()
+}
+
+// unwrapSession is set as tls.Config.UnwrapSession on the server when
+// Config.ResumptionState is set. It parses the session state that
+// wrapSession produced and delivers the application data it carries to
+// Config.ResumptionState, before the handshake confirming resumption has
+// even finished.
+func (c *Conn) unwrapSession(identity []byte, cs tls.ConnectionState) (*tls.SessionState, error) {
+	state, err := tls.ParseSessionState(identity)
+	if err != nil {
+		return nil, err
+	}
+	if len(state.Extra) > 0 && c.config.ResumptionState != nil {
+		c.config.ResumptionState(state.Extra[0])
+	}
+	return state, nil
+}
+
+// discardRejected0RTT is called when the peer rejects our 0-RTT data
+// (tls.QUICRejectedEarlyData on the client). Any stream data we sent
+// speculatively at the Early encryption level is discarded and must be
+// retransmitted once the 1-RTT keys are available.
+func (c *Conn) discardRejected0RTT() {
+	c.streams.discardSentEarlyData()
+}
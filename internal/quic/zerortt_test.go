@@ -0,0 +1,63 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package quic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestZeroRTTTransportParamsRoundTrip(t *testing.T) {
+	p := zeroRTTTransportParams{
+		initialMaxData:                 1000,
+		initialMaxStreamDataBidiLocal:  100,
+		initialMaxStreamDataBidiRemote: 200,
+		initialMaxStreamDataUni:        300,
+		initialMaxStreamsBidi:          10,
+		initialMaxStreamsUni:           5,
+		activeConnIDLimit:              4,
+	}
+	got, ok := unmarshalZeroRTTTransportParams(p.marshal())
+	if !ok {
+		t.Fatalf("unmarshalZeroRTTTransportParams(p.marshal()) ok = false, want true")
+	}
+	if !reflect.DeepEqual(got, p) {
+		t.Errorf("unmarshalZeroRTTTransportParams(p.marshal()) = %+v, want %+v", got, p)
+	}
+}
+
+func TestUnmarshalZeroRTTTransportParamsTruncated(t *testing.T) {
+	p := zeroRTTTransportParams{initialMaxData: 1}
+	b := p.marshal()
+	if _, ok := unmarshalZeroRTTTransportParams(b[:len(b)-1]); ok {
+		t.Error("unmarshalZeroRTTTransportParams(truncated) ok = true, want false")
+	}
+}
+
+func TestZeroRTTParamsFromTransportParameters(t *testing.T) {
+	tp := transportParameters{
+		initialMaxData:                 1000,
+		initialMaxStreamDataBidiLocal:  100,
+		initialMaxStreamDataBidiRemote: 200,
+		initialMaxStreamDataUni:        300,
+		initialMaxStreamsBidi:          10,
+		initialMaxStreamsUni:           5,
+		activeConnIDLimit:              4,
+	}
+	want := zeroRTTTransportParams{
+		initialMaxData:                 1000,
+		initialMaxStreamDataBidiLocal:  100,
+		initialMaxStreamDataBidiRemote: 200,
+		initialMaxStreamDataUni:        300,
+		initialMaxStreamsBidi:          10,
+		initialMaxStreamsUni:           5,
+		activeConnIDLimit:              4,
+	}
+	if got := zeroRTTParamsFromTransportParameters(tp); !reflect.DeepEqual(got, want) {
+		t.Errorf("zeroRTTParamsFromTransportParameters(%+v) = %+v, want %+v", tp, got, want)
+	}
+}